@@ -0,0 +1,42 @@
+package generate
+
+import (
+	"math/rand"
+
+	"github.com/ylacancellera/random-data-load/db"
+)
+
+// Dialect abstracts everything that differs between the SQL backends random-data-load
+// targets: the column type -> generator mapping, identifier escaping, the
+// INSERT statement shape, and the SQL used to randomly sample existing rows
+// for FK "db-random-1-n" sampling.
+type Dialect interface {
+	// Generate returns the Getter to use for field, or nil if field.DataType
+	// isn't recognized by this dialect.
+	Generate(field db.Field, rnd *rand.Rand) Getter
+	Escape(identifier string) string
+	InsertTemplate() string
+	// RandomSampleSQL returns a query selecting up to limit random rows of
+	// schema.table, using whatever random-sampling mechanism the backend
+	// supports (e.g. ORDER BY RAND() for MySQL, TABLESAMPLE for Postgres).
+	RandomSampleSQL(schema, table string, limit int64) string
+}
+
+// DialectAwareSampler is implemented by Sampler implementations whose SQL
+// generation depends on the target dialect (currently "db-random-1-n" and
+// "n-m").
+type DialectAwareSampler interface {
+	Sampler
+	SetDialect(d Dialect)
+}
+
+// DetectDialect picks a Dialect from the connection backing table, falling
+// back to MySQLDialect when the driver isn't recognized.
+func DetectDialect(table *db.Table) Dialect {
+	switch table.Driver {
+	case "postgres", "postgresql", "pgx":
+		return PostgresDialect{}
+	default:
+		return MySQLDialect{}
+	}
+}
@@ -0,0 +1,70 @@
+package generate
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ylacancellera/random-data-load/db"
+)
+
+// MySQLDialect targets MySQL/MariaDB and is the default Dialect when none is
+// detected or specified.
+type MySQLDialect struct{}
+
+var mysqlMaxValues = map[string]int64{
+	"tinyint":   0xF,
+	"smallint":  0xFF,
+	"mediumint": 0x7FFFF,
+	"int":       0x7FFFFFFF,
+	"integer":   0x7FFFFFFF,
+	"float":     0x7FFFFFFF,
+	"decimal":   0x7FFFFFFF,
+	"double":    0x7FFFFFFF,
+	"bigint":    0x7FFFFFFFFFFFFFFF,
+}
+
+func (MySQLDialect) Generate(field db.Field, rnd *rand.Rand) Getter {
+	switch field.DataType {
+	case "tinyint", "bit", "bool", "boolean":
+		return NewRandomIntRange(field.ColumnName, 0, 1, field.IsNullable, rnd)
+	case "smallint", "mediumint", "int", "integer", "bigint":
+		maxValue := mysqlMaxValues["bigint"]
+		if m, ok := mysqlMaxValues[field.DataType]; ok {
+			maxValue = m
+		}
+		return NewRandomInt(field.ColumnName, maxValue, field.IsNullable, rnd)
+	case "float", "decimal", "double", "numeric":
+		return NewRandomDecimal(field.ColumnName, field.NumericPrecision.Int64, field.NumericScale.Int64, field.IsNullable, rnd)
+	case "char", "varchar":
+		return NewRandomString(field.ColumnName, field.CharacterMaximumLength.Int64, field.IsNullable, rnd)
+	case "date":
+		return NewRandomDate(field.ColumnName, field.IsNullable, rnd)
+	case "datetime", "timestamp":
+		return NewRandomDateTime(field.ColumnName, field.IsNullable, rnd)
+	case "tinyblob", "tinytext", "blob", "text", "mediumtext", "mediumblob", "longblob", "longtext":
+		return NewRandomString(field.ColumnName, field.CharacterMaximumLength.Int64, field.IsNullable, rnd)
+	case "time":
+		return NewRandomTime(field.IsNullable, rnd)
+	case "year":
+		return NewRandomIntRange(field.ColumnName, int64(time.Now().Year()-1),
+			int64(time.Now().Year()), field.IsNullable, rnd)
+	case "enum", "set":
+		return NewRandomEnum(field.SetEnumVals, field.IsNullable, rnd)
+	case "binary", "varbinary":
+		return NewRandomBinary(field.ColumnName, field.CharacterMaximumLength.Int64, field.IsNullable, rnd)
+	}
+	return nil
+}
+
+func (MySQLDialect) Escape(identifier string) string {
+	return db.Escape(identifier)
+}
+
+func (MySQLDialect) InsertTemplate() string {
+	return db.InsertTemplate()
+}
+
+func (d MySQLDialect) RandomSampleSQL(schema, table string, limit int64) string {
+	return fmt.Sprintf("SELECT * FROM %s.%s ORDER BY RAND() LIMIT %d", d.Escape(schema), d.Escape(table), limit)
+}
@@ -0,0 +1,107 @@
+package generate
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/ylacancellera/random-data-load/db"
+)
+
+// PostgresDialect targets PostgreSQL, covering the serial/bigserial,
+// jsonb, uuid, bytea, timestamptz, array and numeric(p,s) types on top of
+// the ANSI types shared with MySQLDialect.
+type PostgresDialect struct{}
+
+var postgresMaxValues = map[string]int64{
+	"smallint":         0x7FFF,
+	"smallserial":      0x7FFF,
+	"integer":          0x7FFFFFFF,
+	"serial":           0x7FFFFFFF,
+	"bigint":           0x7FFFFFFFFFFFFFFF,
+	"bigserial":        0x7FFFFFFFFFFFFFFF,
+	"real":             0x7FFFFFFF,
+	"double precision": 0x7FFFFFFFFFFFFFFF,
+}
+
+func (PostgresDialect) Generate(field db.Field, rnd *rand.Rand) Getter {
+	if strings.HasSuffix(field.DataType, "[]") {
+		return postgresRandomArray(field, rnd)
+	}
+
+	switch field.DataType {
+	case "boolean", "bool":
+		// Postgres doesn't implicitly cast integer to boolean in an INSERT
+		// VALUES list, so a bare 0/1 fails; TRUE/FALSE is the literal it expects.
+		return pick(postgresBooleanCandidates, field.IsNullable, rnd)
+	case "smallint", "integer", "bigint", "serial", "bigserial", "smallserial":
+		maxValue := postgresMaxValues["bigint"]
+		if m, ok := postgresMaxValues[field.DataType]; ok {
+			maxValue = m
+		}
+		return NewRandomInt(field.ColumnName, maxValue, field.IsNullable, rnd)
+	case "real", "double precision", "numeric", "decimal":
+		return NewRandomDecimal(field.ColumnName, field.NumericPrecision.Int64, field.NumericScale.Int64, field.IsNullable, rnd)
+	case "char", "character", "varchar", "character varying", "text":
+		return NewRandomString(field.ColumnName, field.CharacterMaximumLength.Int64, field.IsNullable, rnd)
+	case "date":
+		return NewRandomDate(field.ColumnName, field.IsNullable, rnd)
+	case "timestamp", "timestamp without time zone", "timestamptz", "timestamp with time zone":
+		return NewRandomDateTime(field.ColumnName, field.IsNullable, rnd)
+	case "time", "time without time zone", "time with time zone":
+		return NewRandomTime(field.IsNullable, rnd)
+	case "bytea":
+		return NewRandomBinary(field.ColumnName, field.CharacterMaximumLength.Int64, field.IsNullable, rnd)
+	case "uuid":
+		return pick(postgresUUIDCandidates, field.IsNullable, rnd)
+	case "jsonb", "json":
+		return pick(postgresJSONCandidates, field.IsNullable, rnd)
+	}
+	return nil
+}
+
+var postgresBooleanCandidates = []string{"TRUE", "FALSE"}
+
+var postgresUUIDCandidates = generateUUIDCandidates(256)
+
+var postgresJSONCandidates = []string{
+	`{}`, `{"a":1}`, `{"active":true}`, `{"tags":["a","b"]}`, `{"nested":{"x":1}}`,
+}
+
+// postgresRandomArray builds a Postgres array literal (e.g. "{1,2,3}") for
+// column types reported with a "[]" suffix, such as "integer[]".
+func postgresRandomArray(field db.Field, rnd *rand.Rand) Getter {
+	elemType := strings.TrimSuffix(field.DataType, "[]")
+	elemField := field
+	elemField.DataType = elemType
+
+	n := 1 + rnd.Intn(4)
+	elems := make([]string, n)
+	for i := range elems {
+		g := PostgresDialect{}.Generate(elemField, rnd)
+		if g == nil {
+			g = NewRandomInt(field.ColumnName, postgresMaxValues["integer"], false, rnd)
+		}
+		elems[i] = strings.Trim(g.String(), "'")
+	}
+	return pick([]string{"{" + strings.Join(elems, ",") + "}"}, field.IsNullable, rnd)
+}
+
+func (PostgresDialect) Escape(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func (PostgresDialect) InsertTemplate() string {
+	return "INSERT INTO %s.%s (%s) VALUES "
+}
+
+// postgresTableSamplePercent is the fixed BERNOULLI sampling percentage used
+// by RandomSampleSQL. TABLESAMPLE takes a percentage of the table, not a row
+// count like MySQL's "ORDER BY RAND() LIMIT n", so limit is applied
+// separately via LIMIT to match the Dialect.RandomSampleSQL contract of
+// selecting "up to limit random rows".
+const postgresTableSamplePercent = 10
+
+func (d PostgresDialect) RandomSampleSQL(schema, table string, limit int64) string {
+	return fmt.Sprintf("SELECT * FROM %s.%s TABLESAMPLE BERNOULLI (%d) LIMIT %d", d.Escape(schema), d.Escape(table), postgresTableSamplePercent, limit)
+}
@@ -0,0 +1,247 @@
+package generate
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ylacancellera/random-data-load/db"
+)
+
+// Distribution overrides the default uniform random generator for a single
+// column. Columns without a configured Distribution keep using the
+// Provider/Dialect defaults.
+type Distribution interface {
+	Generate(field db.Field, rnd *rand.Rand) Getter
+}
+
+// DistributionConfig configures per-column distributions and value
+// constraints, keyed by "table.column" like ProviderConfig.ColumnProviders.
+type DistributionConfig struct {
+	Zipf            map[string]string `name:"zipf" help:"Zipfian distribution for numeric columns, \"exponent[,max]\". E.g: --zipf=\"orders.customer_id=1.5\""`
+	Normal          map[string]string `name:"normal" help:"Normal distribution \"mean,stddev\" for numeric columns. E.g: --normal=\"users.age=40,12\""`
+	Weighted        map[string]string `name:"weighted" help:"Weighted categorical values \"v1:w1,v2:w2,...\". E.g: --weighted=\"orders.status=active:0.9,cancelled:0.1\""`
+	Regex           map[string]string `name:"regex" help:"Regex-driven string generator. E.g: --regex=\"users.sku=[A-Z]{3}-[0-9]{4}\""`
+	MinMax          map[string]string `name:"min-max" help:"Override numeric bounds \"min,max\". E.g: --min-max=\"orders.amount=0,10000\""`
+	NullProbability map[string]string `name:"null-probability" help:"Override the probability (0-1) that a nullable column gets NULL. E.g: --null-probability=\"users.middle_name=0.8\""`
+}
+
+type resolvedDistributions struct {
+	byColumn         map[string]Distribution
+	nullProbByColumn map[string]float64
+}
+
+func (c DistributionConfig) resolve() (*resolvedDistributions, error) {
+	byColumn := map[string]Distribution{}
+
+	for col, spec := range c.Zipf {
+		parts := strings.Split(spec, ",")
+		exponent, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing zipf exponent for %q", col)
+		}
+		max := uint64(0x7FFFFFFF)
+		if len(parts) > 1 {
+			m, err := strconv.ParseUint(parts[1], 10, 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing zipf max for %q", col)
+			}
+			max = m
+		}
+		if max == 0 {
+			return nil, fmt.Errorf("zipf distribution for %q: max must be > 0, got %d", col, max)
+		}
+		byColumn[col] = &Zipf{Exponent: exponent, Max: max}
+	}
+
+	for col, spec := range c.Normal {
+		parts := strings.Split(spec, ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("normal distribution for %q must be \"mean,stddev\", got %q", col, spec)
+		}
+		mean, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing normal mean for %q", col)
+		}
+		stddev, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing normal stddev for %q", col)
+		}
+		byColumn[col] = Normal{Mean: mean, StdDev: stddev}
+	}
+
+	for col, spec := range c.Weighted {
+		w, err := parseWeighted(spec)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing weighted values for %q", col)
+		}
+		byColumn[col] = w
+	}
+
+	for col, pattern := range c.Regex {
+		byColumn[col] = Regex{Pattern: pattern}
+	}
+
+	for col, spec := range c.MinMax {
+		parts := strings.Split(spec, ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("min-max for %q must be \"min,max\", got %q", col, spec)
+		}
+		min, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing min for %q", col)
+		}
+		max, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing max for %q", col)
+		}
+		byColumn[col] = MinMax{Min: min, Max: max}
+	}
+
+	nullProbByColumn := map[string]float64{}
+	for col, spec := range c.NullProbability {
+		p, err := strconv.ParseFloat(spec, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing null-probability for %q", col)
+		}
+		nullProbByColumn[col] = p
+	}
+
+	return &resolvedDistributions{byColumn: byColumn, nullProbByColumn: nullProbByColumn}, nil
+}
+
+func parseWeighted(spec string) (Weighted, error) {
+	pairs := strings.Split(spec, ",")
+	w := Weighted{Values: make([]string, 0, len(pairs)), Weights: make([]float64, 0, len(pairs))}
+	for _, pair := range pairs {
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 {
+			return Weighted{}, fmt.Errorf("expected \"value:weight\", got %q", pair)
+		}
+		weight, err := strconv.ParseFloat(kv[1], 64)
+		if err != nil {
+			return Weighted{}, err
+		}
+		w.Values = append(w.Values, kv[0])
+		w.Weights = append(w.Weights, weight)
+	}
+	return w, nil
+}
+
+// distributionFor returns the Distribution and null-probability override
+// configured for field on this table, if any.
+func (in *Insert) distributionFor(field db.Field) (Distribution, *float64) {
+	if in.distributions == nil {
+		return nil, nil
+	}
+	key := in.table.Name + "." + field.ColumnName
+	dist := in.distributions.byColumn[key]
+	if p, ok := in.distributions.nullProbByColumn[key]; ok {
+		return dist, &p
+	}
+	return dist, nil
+}
+
+// Zipf is a power-law distribution over integers, useful for simulating
+// hotspots (e.g. a handful of customer_ids receiving most of the orders).
+// Max must be > 0, see DistributionConfig.resolve.
+type Zipf struct {
+	Exponent float64
+	Max      uint64
+
+	// gen is built once, from the *rand.Rand of whichever row generates
+	// this column first, and then reused for every following row. Building
+	// a fresh rand.Zipf per row would be both wasteful and defeat the point
+	// of a hotspot distribution, whose skew only shows up when consecutive
+	// draws come from the same stream.
+	gen *rand.Zipf
+}
+
+func (z *Zipf) Generate(field db.Field, rnd *rand.Rand) Getter {
+	if z.gen == nil {
+		exponent := z.Exponent
+		if exponent <= 1 {
+			exponent = 1.01
+		}
+		z.gen = rand.NewZipf(rnd, exponent, 1, z.Max)
+	}
+	return pick([]string{strconv.FormatUint(z.gen.Uint64(), 10)}, field.IsNullable, rnd)
+}
+
+// Normal is a gaussian distribution for numeric columns.
+type Normal struct {
+	Mean   float64
+	StdDev float64
+}
+
+func (n Normal) Generate(field db.Field, rnd *rand.Rand) Getter {
+	v := rnd.NormFloat64()*n.StdDev + n.Mean
+	return pick([]string{formatNumeric(field, v)}, field.IsNullable, rnd)
+}
+
+// Weighted picks one of Values with probability proportional to the matching
+// entry in Weights, e.g. for enum/set columns like status=active:0.9,cancelled:0.1.
+type Weighted struct {
+	Values  []string
+	Weights []float64
+}
+
+func (w Weighted) Generate(field db.Field, rnd *rand.Rand) Getter {
+	var total float64
+	for _, weight := range w.Weights {
+		total += weight
+	}
+	threshold := rnd.Float64() * total
+	var cumulative float64
+	chosen := w.Values[len(w.Values)-1]
+	for i, weight := range w.Weights {
+		cumulative += weight
+		if threshold <= cumulative {
+			chosen = w.Values[i]
+			break
+		}
+	}
+	return pick([]string{chosen}, field.IsNullable, rnd)
+}
+
+// Regex generates strings matching an RFC-3986-ish regular expression.
+type Regex struct {
+	Pattern string
+	// MaxRepeat bounds unbounded quantifiers (*, +, {n,}); defaults to 10.
+	MaxRepeat int
+}
+
+func (re Regex) Generate(field db.Field, rnd *rand.Rand) Getter {
+	maxRepeat := re.MaxRepeat
+	if maxRepeat <= 0 {
+		maxRepeat = 10
+	}
+	s, err := generateFromRegex(re.Pattern, maxRepeat, rnd)
+	if err != nil {
+		log.Error().Err(err).Str("pattern", re.Pattern).Msg("invalid regex distribution pattern")
+		return nil
+	}
+	return pick([]string{s}, field.IsNullable, rnd)
+}
+
+// MinMax generates a uniform numeric value within [Min, Max), overriding the
+// dialect's default range for this column.
+type MinMax struct {
+	Min, Max float64
+}
+
+func (m MinMax) Generate(field db.Field, rnd *rand.Rand) Getter {
+	v := m.Min + rnd.Float64()*(m.Max-m.Min)
+	return pick([]string{formatNumeric(field, v)}, field.IsNullable, rnd)
+}
+
+func formatNumeric(field db.Field, v float64) string {
+	if field.NumericScale.Int64 > 0 {
+		return strconv.FormatFloat(v, 'f', int(field.NumericScale.Int64), 64)
+	}
+	return strconv.FormatInt(int64(v), 10)
+}
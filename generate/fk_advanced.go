@@ -0,0 +1,231 @@
+package generate
+
+import (
+	"math/rand"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ylacancellera/random-data-load/db"
+)
+
+// RowSampler is implemented by Sampler implementations that can re-sample a
+// single row after the fact. sampleNtoM uses it to break composite-key
+// collisions without having to re-sample the whole batch; samplers that
+// don't implement it just keep the collision.
+type RowSampler interface {
+	Sampler
+	SampleRow(row int) error
+}
+
+// SelfRefSampler is implemented by Sampler implementations that sample a
+// self-referencing foreign key (e.g. employees.manager_id -> employees.id).
+// batch gives access to every column generated or sampled so far for the
+// current Insert.generateRows call, in allFields order, so a self-ref can
+// look up any other column of a row it points at; rootNullProb is the
+// probability that a row gets no parent at all. Insert.sampleSelfRef only
+// calls this once the whole batch has finished generating, see its doc
+// comment for why.
+type SelfRefSampler interface {
+	Sampler
+	SetBatchContext(batch []InsertValues, allFields []db.Field, rootNullProb float64)
+}
+
+// selfRefBatch pairs a self-ref constraint with the subslice of the batch
+// its FK columns live in. sampleFieldsTable defers these to
+// Insert.sampleSelfRef instead of sampling them immediately.
+type selfRefBatch struct {
+	constraint db.Constraint
+	subSlice   [][]Getter
+}
+
+// selfRefSample implements Sampler, SelfRefSampler and SeedableSampler for a
+// self-referencing foreign key. It never touches the database: every row
+// either becomes a root (NULL FK) or points at a row generated earlier in
+// the same batch, so the caller must not call Sample until SetBatchContext
+// has been given the fully generated batch - see Insert.sampleSelfRef.
+type selfRefSample struct {
+	fields   []db.Field
+	schema   string
+	table    string
+	subSlice [][]Getter
+	rnd      *rand.Rand
+
+	batch        []InsertValues
+	allFields    []db.Field
+	rootNullProb float64
+}
+
+// NewSelfRefSample returns a Sampler for a self-referencing foreign key.
+func NewSelfRefSample(fields []db.Field, schema, table string, subSlice [][]Getter) Sampler {
+	return &selfRefSample{fields: fields, schema: schema, table: table, subSlice: subSlice}
+}
+
+func (s *selfRefSample) Seed(r *rand.Rand) {
+	s.rnd = r
+}
+
+func (s *selfRefSample) SetBatchContext(batch []InsertValues, allFields []db.Field, rootNullProb float64) {
+	s.batch = batch
+	s.allFields = allFields
+	s.rootNullProb = rootNullProb
+}
+
+// Sample fills every row of subSlice, in row order, by pointing it at the
+// referenced fields of a row generated earlier in the batch (row index <
+// the row being filled) or NULL for a root row. This only guarantees "no
+// forward references" if batch already holds the final value of every
+// column for every row in [0, len(subSlice)) - it does not order the reads
+// itself.
+func (s *selfRefSample) Sample() error {
+	rnd := s.rnd
+	if rnd == nil {
+		rnd = rand.New(rand.NewSource(1))
+	}
+
+	colIdx := make([]int, len(s.fields))
+	for i, f := range s.fields {
+		colIdx[i] = fieldIndex(s.allFields, f.ColumnName)
+	}
+
+	for row := range s.subSlice {
+		if row == 0 || rnd.Float64() < s.rootNullProb {
+			for col := range s.subSlice[row] {
+				s.subSlice[row][col] = NewRandomEnum(nil, true, rnd)
+			}
+			continue
+		}
+
+		source := rnd.Intn(row)
+		for col, idx := range colIdx {
+			if idx < 0 {
+				s.subSlice[row][col] = NewRandomEnum(nil, true, rnd)
+				continue
+			}
+			s.subSlice[row][col] = s.batch[source][idx]
+		}
+	}
+	return nil
+}
+
+func fieldIndex(fields []db.Field, columnName string) int {
+	for i, f := range fields {
+		if f.ColumnName == columnName {
+			return i
+		}
+	}
+	return -1
+}
+
+// ntoMSampler adapts NewDBRandomSample with a real RowSampler.SampleRow, so
+// sampleNtoM's collision retry loop can actually re-draw a single row
+// instead of giving up on the first duplicate pair.
+type ntoMSampler struct {
+	Sampler
+	fields   []db.Field
+	schema   string
+	table    string
+	subSlice [][]Getter
+	rnd      *rand.Rand
+	dialect  Dialect
+}
+
+func newNtoMSampler(fields []db.Field, schema, table string, subSlice [][]Getter) *ntoMSampler {
+	return &ntoMSampler{
+		Sampler:  NewDBRandomSample(fields, schema, table, subSlice),
+		fields:   fields,
+		schema:   schema,
+		table:    table,
+		subSlice: subSlice,
+	}
+}
+
+// Seed remembers r so SampleRow's one-off samplers can be seeded the same
+// way, in addition to seeding the wrapped batch sampler.
+func (s *ntoMSampler) Seed(r *rand.Rand) {
+	s.rnd = r
+	if seedable, ok := s.Sampler.(SeedableSampler); ok {
+		seedable.Seed(r)
+	}
+}
+
+// SetDialect remembers d for the same reason as Seed.
+func (s *ntoMSampler) SetDialect(d Dialect) {
+	s.dialect = d
+	if aware, ok := s.Sampler.(DialectAwareSampler); ok {
+		aware.SetDialect(d)
+	}
+}
+
+// SampleRow re-samples a single row in place: it draws a fresh one-row batch
+// into a slice that aliases subSlice[row]'s backing array, so the result
+// lands directly in the shared batch without any extra copying.
+func (s *ntoMSampler) SampleRow(row int) error {
+	one := NewDBRandomSample(s.fields, s.schema, s.table, s.subSlice[row:row+1])
+	if seedable, ok := one.(SeedableSampler); ok && s.rnd != nil {
+		seedable.Seed(s.rnd)
+	}
+	if aware, ok := one.(DialectAwareSampler); ok && s.dialect != nil {
+		aware.SetDialect(s.dialect)
+	}
+	return one.Sample()
+}
+
+// sampleNtoM samples both foreign keys of one or more many-to-many join
+// constraints and retries per-row collisions, up to maxRetries times per row,
+// so that the sampled (fkA, fkB, ...) pair stays unique across the generated
+// batch; a collision still there after the last retry is kept.
+func (in *Insert) sampleNtoM(constraints []db.Constraint, subSlices [][][]Getter, rowOffset int64) error {
+	samplers := make([]Sampler, len(constraints))
+	for i, constraint := range constraints {
+		sampler := in.createSamplerFromForeignkeyLinks("n-m", constraint, subSlices[i])
+		in.wireSampler(sampler, rowOffset, constraint.ReferencedTableName)
+		if err := sampler.Sample(); err != nil {
+			return errors.Wrap(err, "sampling n-m side")
+		}
+		samplers[i] = sampler
+	}
+
+	if len(subSlices) == 0 || len(subSlices[0]) == 0 {
+		return nil
+	}
+
+	const maxRetries = 20
+	seen := make(map[string]bool, len(subSlices[0]))
+	for row := range subSlices[0] {
+		key := ntoMKey(subSlices, row)
+		for retries := 0; seen[key] && retries < maxRetries; retries++ {
+			resampled := false
+			for _, sampler := range samplers {
+				if rowSampler, ok := sampler.(RowSampler); ok {
+					if err := rowSampler.SampleRow(row); err != nil {
+						return errors.Wrap(err, "resampling n-m row")
+					}
+					resampled = true
+				}
+			}
+			if !resampled {
+				// none of the samplers can resample a single row; keep the collision
+				break
+			}
+			key = ntoMKey(subSlices, row)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// ntoMKey builds a collision key for row out of every sampled FK column
+// across all join-table constraints, so a pair is only considered a
+// duplicate when every column matches.
+func ntoMKey(subSlices [][][]Getter, row int) string {
+	var b strings.Builder
+	for _, sub := range subSlices {
+		for _, g := range sub[row] {
+			b.WriteString(g.String())
+			b.WriteByte(0)
+		}
+		b.WriteByte(0x1f)
+	}
+	return b.String()
+}
@@ -3,6 +3,7 @@ package generate
 import (
 	"fmt"
 	"io"
+	"math/rand"
 	"os"
 	"strings"
 	"sync"
@@ -15,46 +16,90 @@ import (
 )
 
 type Insert struct {
-	table      *db.Table
-	writer     io.Writer
-	notifyChan chan int64
-	fklinks    ForeignKeyLinks
+	table         *db.Table
+	writer        io.Writer
+	notifyChan    chan int64
+	fklinks       ForeignKeyLinks
+	providers     *resolvedProviders
+	distributions *resolvedDistributions
+	dialect       Dialect
+	loadMode      LoadMode
+	seed          int64
+	rowOffset     int64
+}
+
+// SeedableSampler is implemented by Sampler implementations that can draw
+// deterministically from a *rand.Rand instead of the global math/rand source.
+// Samplers that don't implement it keep sampling non-deterministically even
+// when Insert.Seed has been called.
+type SeedableSampler interface {
+	Sampler
+	Seed(r *rand.Rand)
 }
 
 type ForeignKeyLinks struct {
 	DefaultRelationship string            `name:"default-relationship" enum:"random-1-n,1-1" default:"random-1-n"`
 	DBRandomOneToMany   map[string]string `name:"db-random-1-n" help:"foreignkey links to 1-N relationships using postgres' tablesamples Bernouilli random or mysql RAND() < 0.1. E.g: --random-1-n=\"customers=orders;orders=items\""`
 	OneToOne            map[string]string `name:"1-1" help:"Override foreignkey links to 1-1 relationships. E.g: --1-1=\"citizens=ssns\""`
+	NtoM                map[string]string `name:"n-m" help:"Foreignkey links from a join table to the comma-separated tables its FKs reference. A sampled pair that repeats within the same bulk insert is re-sampled up to 20 times, then kept. E.g: --n-m=\"users_roles=users,roles\""`
+	SelfRef             map[string]string `name:"self-ref" help:"Foreignkey links to a self-referencing hierarchy, only sampling rows already generated earlier in the same bulk insert. E.g: --self-ref=\"employees=employees\""`
+	SelfRefNullRootProb float64           `name:"self-ref-null-root-prob" help:"Probability that a self-ref row other than the first of its bulk insert has no parent (becomes a root). The first row of every bulk insert is always a root." default:"0.05"`
 }
 
-var (
-	maxValues = map[string]int64{
-		"tinyint":   0xF,
-		"smallint":  0xFF,
-		"mediumint": 0x7FFFF,
-		"int":       0x7FFFFFFF,
-		"integer":   0x7FFFFFFF,
-		"float":     0x7FFFFFFF,
-		"decimal":   0x7FFFFFFF,
-		"double":    0x7FFFFFFF,
-		"bigint":    0x7FFFFFFFFFFFFFFF,
+// New returns a new Insert instance. A nil dialect is auto-detected from
+// table's connection, see DetectDialect.
+func New(table *db.Table, fklinks ForeignKeyLinks, dialect Dialect) *Insert {
+	if dialect == nil {
+		dialect = DetectDialect(table)
 	}
-)
-
-// New returns a new Insert instance.
-func New(table *db.Table, fklinks ForeignKeyLinks) *Insert {
 	return &Insert{
 		table:   table,
 		writer:  os.Stdout,
 		fklinks: fklinks,
+		dialect: dialect,
+		seed:    time.Now().UnixNano(),
 	}
 }
 
+// Seed makes Run/DryRun deterministic for generated columns and for FK
+// sampling on SeedableSampler implementations (1-1, self-ref): given the
+// same DB snapshot, two Inserts seeded with the same value produce
+// byte-identical output for those columns. db-random-1-n and n-m (which
+// samples through the same query) are excluded from that guarantee: they rely
+// on the server's own ORDER BY RAND()/TABLESAMPLE, which a client-side seed
+// can't pin.
+func (in *Insert) Seed(seed int64) {
+	in.seed = seed
+}
+
 // SetWriter lets you specify a custom writer. The default is Stdout.
 func (in *Insert) SetWriter(w io.Writer) {
 	in.writer = w
 }
 
+// SetProviders configures per-column value providers used instead of the
+// default type-based random generators, see ProviderConfig.
+func (in *Insert) SetProviders(cfg ProviderConfig) error {
+	resolved, err := cfg.resolve()
+	if err != nil {
+		return errors.Wrap(err, "resolving providers")
+	}
+	in.providers = resolved
+	return nil
+}
+
+// SetDistributions configures per-column statistical distributions and value
+// constraints that override the default uniform random generators, see
+// DistributionConfig.
+func (in *Insert) SetDistributions(cfg DistributionConfig) error {
+	resolved, err := cfg.resolve()
+	if err != nil {
+		return errors.Wrap(err, "resolving distributions")
+	}
+	in.distributions = resolved
+	return nil
+}
+
 func (in *Insert) NotifyChan() chan int64 {
 	if in.notifyChan != nil {
 		close(in.notifyChan)
@@ -121,24 +166,12 @@ func (in *Insert) notify(n int64) {
 }
 
 // generate field and sample fields in parallel, since both operations are slow
-func (in *Insert) genQuery(count int64) *string {
-
-	if count < 1 {
-		return nil
-	}
+func (in *Insert) generateRows(count int64) ([]db.Field, []db.Field, []InsertValues) {
+	rowOffset := in.rowOffset
+	in.rowOffset += count
 
 	fieldsToGen := in.table.FieldsToGenerate()
 	fieldsToSample := in.table.FieldsToSample()
-	var insertQuery strings.Builder
-	_, err := insertQuery.WriteString(fmt.Sprintf(db.InsertTemplate(), //nolint
-		db.Escape(in.table.Schema),
-		db.Escape(in.table.Name),
-		db.EscapedNamesListFromFields(append(fieldsToGen, fieldsToSample...)),
-	))
-	if err != nil {
-		log.Error().Err(err).Msg("failed to build string")
-	}
-	log.Debug().Str("fieldsToGen", db.EscapedNamesListFromFields(fieldsToGen)).Str("fieldsToSample", db.EscapedNamesListFromFields(fieldsToSample)).Str("table", in.table.Name).Str("schema", in.table.Schema).Msg("genQuery init")
 
 	// TODO obj pool ?
 	// full init of the 2 layer slice
@@ -153,12 +186,14 @@ func (in *Insert) genQuery(count int64) *string {
 		wg.Add(1)
 		go func() {
 			for i := int64(0); i < count; i++ {
-				generateFieldsRow(fieldsToGen, values[i][:len(fieldsToGen)])
+				rnd := rand.New(rand.NewSource(deriveSeed(in.seed, rowOffset+i)))
+				in.generateFieldsRow(fieldsToGen, values[i][:len(fieldsToGen)], rnd)
 			}
 			wg.Done()
 		}()
 	}
 
+	var selfRefWork []selfRefBatch
 	if len(fieldsToSample) != 0 {
 		wg.Add(1)
 		go func() {
@@ -170,15 +205,53 @@ func (in *Insert) genQuery(count int64) *string {
 			for i := range sampledValues {
 				sampledValues[i] = values[i][len(fieldsToGen):]
 			}
-			err := in.sampleFieldsTable(fieldsToSample, sampledValues)
+			work, err := in.sampleFieldsTable(fieldsToSample, sampledValues, rowOffset)
 			if err != nil {
 				log.Error().Err(err).Msg("error when sampling field")
 			}
+			selfRefWork = work
 			wg.Done()
 		}()
 	}
 
 	wg.Wait()
+
+	// self-ref can only run once every other column of the batch - including
+	// the ones the fieldsToGen goroutine above writes - has its final value,
+	// since a self-ref row looks up arbitrary columns of an earlier row. Doing
+	// this before wg.Wait() would both race on values and make "earlier" mean
+	// nothing, since nothing would yet be settled.
+	if len(selfRefWork) > 0 {
+		allFields := append(append([]db.Field{}, fieldsToGen...), fieldsToSample...)
+		for _, w := range selfRefWork {
+			if err := in.sampleSelfRef(w, rowOffset, allFields, values); err != nil {
+				log.Error().Err(err).Msg("error when sampling self-ref field")
+			}
+		}
+	}
+
+	return fieldsToGen, fieldsToSample, values
+}
+
+func (in *Insert) genQuery(count int64) *string {
+
+	if count < 1 {
+		return nil
+	}
+
+	fieldsToGen, fieldsToSample, values := in.generateRows(count)
+
+	var insertQuery strings.Builder
+	_, err := insertQuery.WriteString(fmt.Sprintf(in.dialect.InsertTemplate(), //nolint
+		in.dialect.Escape(in.table.Schema),
+		in.dialect.Escape(in.table.Name),
+		db.EscapedNamesListFromFields(append(fieldsToGen, fieldsToSample...)),
+	))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to build string")
+	}
+	log.Debug().Str("fieldsToGen", db.EscapedNamesListFromFields(fieldsToGen)).Str("fieldsToSample", db.EscapedNamesListFromFields(fieldsToSample)).Str("table", in.table.Name).Str("schema", in.table.Schema).Msg("genQuery init")
+
 	for row := range values {
 		if values[row] == nil {
 			continue
@@ -198,15 +271,24 @@ func (in *Insert) insert(count int64, dryRun bool) (int64, error) {
 		return 0, nil
 	}
 
-	insertQuery := in.genQuery(count)
-
 	if dryRun {
+		insertQuery := in.genQuery(count)
 		if _, err := in.writer.Write([]byte(*insertQuery + "\n")); err != nil {
 			return 0, err
 		}
 		return count, nil
 	}
 
+	switch in.loadMode {
+	case LoadModePrepared:
+		return in.insertPrepared(count)
+	case LoadModeCopy:
+		return in.insertCopy(count)
+	case LoadModeLoadData:
+		return in.insertLoadData(count)
+	}
+
+	insertQuery := in.genQuery(count)
 	res, err := db.DB.Exec(*insertQuery)
 	if err != nil {
 		log.Error().Str("query", *insertQuery).Err(err).Msg("failed to insert")
@@ -216,50 +298,49 @@ func (in *Insert) insert(count int64, dryRun bool) (int64, error) {
 	return ra, err
 }
 
-func generateFieldsRow(fields []db.Field, insertValues []Getter) {
+func (in *Insert) generateFieldsRow(fields []db.Field, insertValues []Getter, rnd *rand.Rand) {
 	for colIndex := range insertValues {
 		field := fields[colIndex]
-		var value Getter
-		switch field.DataType {
-		case "tinyint", "bit", "bool", "boolean":
-			value = NewRandomIntRange(field.ColumnName, 0, 1, field.IsNullable)
-		case "smallint", "mediumint", "int", "integer", "bigint":
-			maxValue := maxValues["bigint"]
-			if m, ok := maxValues[field.DataType]; ok {
-				maxValue = m
+
+		dist, nullProb := in.distributionFor(field)
+		if nullProb != nil && field.IsNullable {
+			if rnd.Float64() < *nullProb {
+				insertValues[colIndex] = NewRandomEnum(nil, true, rnd)
+				continue
 			}
-			value = NewRandomInt(field.ColumnName, maxValue, field.IsNullable)
-		case "float", "decimal", "double", "numeric":
-			value = NewRandomDecimal(field.ColumnName, field.NumericPrecision.Int64, field.NumericScale.Int64, field.IsNullable)
-		case "char", "varchar":
-			value = NewRandomString(field.ColumnName, field.CharacterMaximumLength.Int64, field.IsNullable)
-		case "date":
-			value = NewRandomDate(field.ColumnName, field.IsNullable)
-		case "datetime", "timestamp":
-			value = NewRandomDateTime(field.ColumnName, field.IsNullable)
-		case "tinyblob", "tinytext", "blob", "text", "mediumtext", "mediumblob", "longblob", "longtext":
-			value = NewRandomString(field.ColumnName, field.CharacterMaximumLength.Int64, field.IsNullable)
-		case "time":
-			value = NewRandomTime(field.IsNullable)
-		case "year":
-			value = NewRandomIntRange(field.ColumnName, int64(time.Now().Year()-1),
-				int64(time.Now().Year()), field.IsNullable)
-		case "enum", "set":
-			value = NewRandomEnum(field.SetEnumVals, field.IsNullable)
-		case "binary", "varbinary":
-			value = NewRandomBinary(field.ColumnName, field.CharacterMaximumLength.Int64, field.IsNullable)
-		default:
+			// the override alone decides NULL, so the generator below must not add its own chance
+			field.IsNullable = false
+		}
+		if dist != nil {
+			insertValues[colIndex] = dist.Generate(field, rnd)
+			continue
+		}
+
+		if p := in.providerFor(field); p != nil {
+			insertValues[colIndex] = p.Generate(field, in.providers.locale, rnd)
+			continue
+		}
+		value := in.dialect.Generate(field, rnd)
+		if value == nil {
 			log.Error().Str("type", field.DataType).Str("field", field.ColumnName).Msg("unsupported datatypes when generating fields")
 		}
 		insertValues[colIndex] = value
 	}
 }
 
-func (in *Insert) sampleFieldsTable(fields []db.Field, values [][]Getter) error {
+// sampleFieldsTable samples every FK column that doesn't need to wait for
+// the rest of the batch to settle first. n-m and self-ref constraints are
+// deferred - n-m to the end of this call (see sampleNtoM), self-ref to the
+// caller once the whole batch has finished generating (see sampleSelfRef).
+func (in *Insert) sampleFieldsTable(fields []db.Field, values [][]Getter, rowOffset int64) ([]selfRefBatch, error) {
 
 	colIdx := 0
 
 	var err error
+	var nmConstraints []db.Constraint
+	var nmSubSlices [][][]Getter
+	var selfRefWork []selfRefBatch
+
 	for _, constraint := range in.table.Constraints {
 
 		// subslice stores only a few columns grouped together with the FK columns
@@ -267,23 +348,78 @@ func (in *Insert) sampleFieldsTable(fields []db.Field, values [][]Getter) error
 		for i := range subSlice {
 			subSlice[i] = values[i][colIdx : colIdx+len(constraint.ReferencedFields)]
 		}
+		colIdx += len(constraint.ReferencedFields)
 
 		fklink := in.fklinks.relationship(in.table.Name, constraint.ReferencedTableName)
+
+		// n-m needs both FK columns of the join table sampled together to
+		// enforce pair uniqueness, so it's deferred until every other
+		// constraint has been collected below.
+		if fklink == "n-m" {
+			nmConstraints = append(nmConstraints, constraint)
+			nmSubSlices = append(nmSubSlices, subSlice)
+			continue
+		}
+
+		if fklink == "self-ref" {
+			selfRefWork = append(selfRefWork, selfRefBatch{constraint: constraint, subSlice: subSlice})
+			continue
+		}
+
 		sampler := in.createSamplerFromForeignkeyLinks(fklink, constraint, subSlice)
+		in.wireSampler(sampler, rowOffset, constraint.ReferencedTableName)
 		err = sampler.Sample()
 		if err != nil {
-			return errors.Wrap(err, "sampleFieldsTable")
+			return nil, errors.Wrap(err, "sampleFieldsTable")
 		}
-		colIdx += len(constraint.ReferencedFields)
+	}
 
+	if len(nmConstraints) > 0 {
+		if err := in.sampleNtoM(nmConstraints, nmSubSlices, rowOffset); err != nil {
+			return nil, errors.Wrap(err, "sampleFieldsTable n-m")
+		}
+	}
+
+	return selfRefWork, nil
+}
+
+// sampleSelfRef runs self-ref FK sampling for one constraint against the
+// fully generated batch. allFields and fullRows must already hold the final
+// value of every column of the batch, since a self-ref row can only point at
+// a row generated earlier in the same batch and needs to read arbitrary
+// columns of it - see Insert.generateRows for why this can't run
+// concurrently with the rest of the batch.
+func (in *Insert) sampleSelfRef(w selfRefBatch, rowOffset int64, allFields []db.Field, fullRows []InsertValues) error {
+	sampler := in.createSamplerFromForeignkeyLinks("self-ref", w.constraint, w.subSlice)
+	in.wireSampler(sampler, rowOffset, w.constraint.ReferencedTableName)
+	if selfRef, ok := sampler.(SelfRefSampler); ok {
+		selfRef.SetBatchContext(fullRows, allFields, in.fklinks.SelfRefNullRootProb)
+	}
+	return sampler.Sample()
+}
+
+func (in *Insert) wireSampler(sampler Sampler, rowOffset int64, refTableName string) {
+	if seedable, ok := sampler.(SeedableSampler); ok {
+		samplerSeed := deriveSeed(in.seed, rowOffset) ^ hashSeed(refTableName)
+		seedable.Seed(rand.New(rand.NewSource(samplerSeed)))
+	}
+	if dialectAware, ok := sampler.(DialectAwareSampler); ok {
+		dialectAware.SetDialect(in.dialect)
 	}
-	return nil
 }
 
 func (r ForeignKeyLinks) relationship(tableName, refTableName string) string {
 	if r.OneToOne[tableName] == refTableName {
 		return "1-1"
 	}
+	if r.SelfRef[tableName] == refTableName {
+		return "self-ref"
+	}
+	for _, ref := range strings.Split(r.NtoM[tableName], ",") {
+		if ref == refTableName {
+			return "n-m"
+		}
+	}
 	if r.DBRandomOneToMany[tableName] == refTableName {
 		return "db-random-1-n"
 	}
@@ -292,15 +428,22 @@ func (r ForeignKeyLinks) relationship(tableName, refTableName string) string {
 
 // not fancy, but could not find a normal solution to interface the "sampler" together in a way like
 //
-// var fkLinkToSamplerCreator = map[string]func([]db.Field, string, string, [][]Getter) Sampler{
-//	"1-1": NewRandomSample,
-// }
+//	var fkLinkToSamplerCreator = map[string]func([]db.Field, string, string, [][]Getter) Sampler{
+//		"1-1": NewRandomSample,
+//	}
 func (in *Insert) createSamplerFromForeignkeyLinks(fklink string, constraint db.Constraint, subSlice [][]Getter) Sampler {
 	switch fklink {
 	case "1-1":
 		return NewUniformSample(constraint.ReferencedFields, constraint.ReferencedTableSchema, constraint.ReferencedTableName, subSlice)
 	case "db-random-1-n":
 		return NewDBRandomSample(constraint.ReferencedFields, constraint.ReferencedTableSchema, constraint.ReferencedTableName, subSlice)
+	case "n-m":
+		// newNtoMSampler wraps NewDBRandomSample with a real RowSampler, so
+		// sampleNtoM can re-draw a single colliding row instead of just the
+		// whole batch, see RowSampler.
+		return newNtoMSampler(constraint.ReferencedFields, constraint.ReferencedTableSchema, constraint.ReferencedTableName, subSlice)
+	case "self-ref":
+		return NewSelfRefSample(constraint.ReferencedFields, constraint.ReferencedTableSchema, constraint.ReferencedTableName, subSlice)
 	}
 	return NewDBRandomSample(constraint.ReferencedFields, constraint.ReferencedTableSchema, constraint.ReferencedTableName, subSlice)
 }
@@ -0,0 +1,250 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+
+	"github.com/ylacancellera/random-data-load/db"
+)
+
+// LoadMode selects how a generated batch is pushed to the database.
+// DryRun always uses the human-readable SQL string regardless of LoadMode.
+type LoadMode string
+
+const (
+	// LoadModeString builds one big "INSERT ... VALUES (...), (...)" string
+	// and runs it through database/sql.Exec. This is the historical, default
+	// behavior and the only mode available in DryRun.
+	LoadModeString LoadMode = "string"
+	// LoadModePrepared prepares the INSERT once per bulk insert and executes
+	// it per row, avoiding the cost of re-parsing/re-escaping a giant string.
+	LoadModePrepared LoadMode = "prepared"
+	// LoadModeCopy streams rows to Postgres via the COPY protocol (pgx).
+	LoadModeCopy LoadMode = "copy"
+	// LoadModeLoadData streams rows to a CSV file and issues MySQL's
+	// LOAD DATA LOCAL INFILE.
+	LoadModeLoadData LoadMode = "loaddata"
+)
+
+// SetLoadMode selects the execution backend used by Run (DryRun is
+// unaffected). The zero value behaves like LoadModeString. LoadModeCopy only
+// works against PostgresDialect (it drives pgx's CopyFrom) and LoadModeLoadData
+// only works against MySQLDialect (it issues LOAD DATA LOCAL INFILE), so both
+// are rejected upfront instead of failing with a type-assertion panic deep in
+// insertCopy/insertLoadData.
+func (in *Insert) SetLoadMode(mode LoadMode) error {
+	switch mode {
+	case "", LoadModeString, LoadModePrepared:
+	case LoadModeCopy:
+		if _, ok := in.dialect.(PostgresDialect); !ok {
+			return errors.Errorf("load mode %q requires the postgres dialect, got %T", mode, in.dialect)
+		}
+	case LoadModeLoadData:
+		if _, ok := in.dialect.(MySQLDialect); !ok {
+			return errors.Errorf("load mode %q requires the mysql dialect, got %T", mode, in.dialect)
+		}
+	default:
+		return errors.Errorf("unknown load mode %q", mode)
+	}
+	in.loadMode = mode
+	return nil
+}
+
+// ValueGetter is implemented by Getter values that can expose their
+// underlying Go value. LoadModePrepared and LoadModeCopy bind driver
+// arguments instead of inlining SQL literals, so they use it in preference to
+// rawValue's fallback below.
+type ValueGetter interface {
+	Getter
+	Value() interface{}
+}
+
+// rawValue returns the Go value g represents, for backends that bind driver
+// arguments instead of inlining SQL. Getter has no other way to expose what
+// it generated besides its escaped SQL literal, so a Getter that doesn't
+// implement ValueGetter falls back to parsing that literal back into a value
+// - binding the literal text itself (quotes, doubled-quote escapes, the
+// string "NULL") would corrupt every row.
+func rawValue(g Getter) interface{} {
+	if vg, ok := g.(ValueGetter); ok {
+		return vg.Value()
+	}
+	return parseSQLLiteral(g.String())
+}
+
+// parseSQLLiteral turns an already-escaped SQL literal as produced by
+// Getter.String (e.g. "'John'", "NULL", "42") back into a Go value, turning
+// each doubled quote inside a quoted literal back into a single quote.
+func parseSQLLiteral(s string) interface{} {
+	if strings.EqualFold(s, "NULL") {
+		return nil
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+	return s
+}
+
+func columnNames(fields []db.Field) []string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = f.ColumnName
+	}
+	return names
+}
+
+func (in *Insert) insertPrepared(count int64) (int64, error) {
+	fieldsToGen, fieldsToSample, rows := in.generateRows(count)
+	allFields := append(fieldsToGen, fieldsToSample...)
+
+	// Postgres drivers take numbered $n placeholders instead of MySQL's ?.
+	marks := make([]string, len(allFields))
+	for i := range marks {
+		marks[i] = "?"
+		if _, ok := in.dialect.(PostgresDialect); ok {
+			marks[i] = fmt.Sprintf("$%d", i+1)
+		}
+	}
+	placeholders := strings.Join(marks, ",")
+	query := fmt.Sprintf("INSERT INTO %s.%s (%s) VALUES (%s)",
+		in.dialect.Escape(in.table.Schema), in.dialect.Escape(in.table.Name),
+		db.EscapedNamesListFromFields(allFields), placeholders)
+
+	stmt, err := db.DB.Prepare(query)
+	if err != nil {
+		return 0, errors.Wrap(err, "preparing insert statement")
+	}
+	defer stmt.Close()
+
+	var okCount int64
+	for _, row := range rows {
+		args := make([]interface{}, len(row))
+		for i, g := range row {
+			args[i] = rawValue(g)
+		}
+		if _, err := stmt.Exec(args...); err != nil {
+			log.Error().Err(err).Str("query", query).Msg("failed prepared insert")
+			return okCount, err
+		}
+		okCount++
+	}
+	return okCount, nil
+}
+
+func (in *Insert) insertCopy(count int64) (int64, error) {
+	fieldsToGen, fieldsToSample, rows := in.generateRows(count)
+	columns := columnNames(append(fieldsToGen, fieldsToSample...))
+
+	copyRows := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		args := make([]interface{}, len(row))
+		for j, g := range row {
+			args[j] = rawValue(g)
+		}
+		copyRows[i] = args
+	}
+
+	conn, err := db.DB.Conn(context.Background())
+	if err != nil {
+		return 0, errors.Wrap(err, "acquiring connection for COPY")
+	}
+	defer conn.Close() //nolint:errcheck
+
+	var n int64
+	err = conn.Raw(func(driverConn interface{}) error {
+		pgxConn := driverConn.(*stdlib.Conn).Conn()
+		copied, copyErr := pgxConn.CopyFrom(
+			context.Background(),
+			pgx.Identifier{in.table.Schema, in.table.Name},
+			columns,
+			pgx.CopyFromRows(copyRows),
+		)
+		n = copied
+		return copyErr
+	})
+	if err != nil {
+		return n, errors.Wrap(err, "COPY FROM")
+	}
+	return n, nil
+}
+
+// loadDataField formats v the way the LOAD DATA statement built by
+// insertLoadData expects to read it back: NULL as the bare, unquoted \N
+// marker (quoting it would make MySQL read it as the literal string "\N"
+// instead of SQL NULL), everything else enclosed in double quotes with
+// backslash escaping, matching the OPTIONALLY ENCLOSED BY '"' clause below so
+// values containing a comma, quote or newline (lorem text, "New York, NY")
+// don't get mis-split into extra columns.
+func loadDataField(v interface{}) string {
+	if v == nil {
+		return `\N`
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range fmt.Sprintf("%v", v) {
+		switch r {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+func (in *Insert) insertLoadData(count int64) (int64, error) {
+	fieldsToGen, fieldsToSample, rows := in.generateRows(count)
+	allFields := append(fieldsToGen, fieldsToSample...)
+
+	f, err := os.CreateTemp("", "random-data-load-*.csv")
+	if err != nil {
+		return 0, errors.Wrap(err, "creating temp CSV file")
+	}
+	defer os.Remove(f.Name()) //nolint:errcheck
+
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, g := range row {
+			record[i] = loadDataField(rawValue(g))
+		}
+		if _, err := f.WriteString(strings.Join(record, ",") + "\n"); err != nil {
+			f.Close() //nolint:errcheck
+			return 0, errors.Wrap(err, "writing CSV row")
+		}
+	}
+	if err := f.Close(); err != nil {
+		return 0, errors.Wrap(err, "closing CSV")
+	}
+
+	query := fmt.Sprintf("LOAD DATA LOCAL INFILE '%s' INTO TABLE %s.%s FIELDS TERMINATED BY ',' OPTIONALLY ENCLOSED BY '\"' (%s)",
+		strings.ReplaceAll(f.Name(), `'`, `\'`),
+		in.dialect.Escape(in.table.Schema), in.dialect.Escape(in.table.Name),
+		strings.Join(columnNames(allFields), ","))
+
+	res, err := db.DB.Exec(query)
+	if err != nil {
+		return 0, errors.Wrap(err, "LOAD DATA LOCAL INFILE")
+	}
+	ra, _ := res.RowsAffected()
+	return ra, nil
+}
@@ -0,0 +1,177 @@
+package generate
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/ylacancellera/random-data-load/db"
+)
+
+// Provider generates realistic, domain-specific values for a field, as an
+// alternative to the type-based random generators in generateFieldsRow.
+// locale is the resolved ProviderConfig.Locale for the running Insert;
+// providers that aren't locale-aware can ignore it. Third parties can
+// implement Provider and register it with RegisterProvider to plug in
+// custom data without forking.
+type Provider interface {
+	Generate(field db.Field, locale string, rnd *rand.Rand) Getter
+}
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]Provider{
+		"name":         nameProvider{},
+		"email":        emailProvider{},
+		"phone":        phoneProvider{},
+		"address.city": addressCityProvider{},
+		"address":      addressProvider{},
+		"company":      companyProvider{},
+		"lorem":        loremProvider{},
+		"creditcard":   creditCardProvider{},
+		"ip":           ipProvider{},
+		"uuid":         uuidProvider{},
+	}
+)
+
+// RegisterProvider makes a Provider available under name, so it can be
+// selected via ProviderConfig.ColumnProviders or matched by a heuristic.
+// Registering under an existing name replaces it.
+func RegisterProvider(name string, p Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = p
+}
+
+func lookupProvider(name string) Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	return providers[name]
+}
+
+// heuristic matches a column name against a provider when no explicit
+// --column-provider override is given.
+type heuristic struct {
+	Pattern  string `yaml:"pattern"`
+	Provider string `yaml:"provider"`
+
+	re *regexp.Regexp
+}
+
+// defaultHeuristics is the built-in column name -> provider matching table.
+// It can be overridden wholesale via ProviderConfig.HeuristicsFile.
+var defaultHeuristics = []heuristic{
+	{Pattern: `(?i)e[-_]?mail`, Provider: "email"},
+	{Pattern: `(?i)phone|tel(ephone)?`, Provider: "phone"},
+	{Pattern: `(?i)city`, Provider: "address.city"},
+	{Pattern: `(?i)ip[-_]?address|^ip$`, Provider: "ip"},
+	{Pattern: `(?i)address|street`, Provider: "address"},
+	{Pattern: `(?i)company|employer`, Provider: "company"},
+	{Pattern: `(?i)(first|last|full|given|family)[-_]?name`, Provider: "name"},
+	{Pattern: `(?i)^name$`, Provider: "name"},
+	{Pattern: `(?i)description|comment|bio`, Provider: "lorem"},
+	{Pattern: `(?i)card[-_]?number|cc[-_]?num`, Provider: "creditcard"},
+	{Pattern: `(?i)uuid|guid`, Provider: "uuid"},
+}
+
+// ProviderConfig configures the provider subsystem. It is passed to
+// Insert.SetProviders and mirrors the tag conventions used by ForeignKeyLinks
+// so it can be wired into the same CLI parser.
+type ProviderConfig struct {
+	ColumnProviders map[string]string `name:"column-provider" help:"Force a provider for a column. E.g: --column-provider=\"users.email=email;users.city=address.city\""`
+	Locale          string            `name:"locale" default:"en_US" help:"Locale used by name/email/address/city/company providers, e.g. en_US, fr_FR"`
+	HeuristicsFile  string            `name:"provider-heuristics" help:"YAML file overriding the default column-name to provider heuristics"`
+}
+
+func (c ProviderConfig) heuristics() ([]heuristic, error) {
+	table := append([]heuristic{}, defaultHeuristics...)
+	if c.HeuristicsFile != "" {
+		overridden, err := loadHeuristics(c.HeuristicsFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "loading provider heuristics")
+		}
+		table = overridden
+	}
+	for i := range table {
+		re, err := regexp.Compile(table[i].Pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "compiling heuristic pattern %q", table[i].Pattern)
+		}
+		table[i].re = re
+	}
+	return table, nil
+}
+
+func loadHeuristics(path string) ([]heuristic, error) {
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var table []heuristic
+	if err := yaml.NewDecoder(f).Decode(&table); err != nil {
+		return nil, errors.Wrap(err, "decoding yaml")
+	}
+	return table, nil
+}
+
+// resolvedProviders is built once from a ProviderConfig and used on the hot
+// path by Insert.providerFor.
+type resolvedProviders struct {
+	locale     string
+	explicit   map[string]Provider
+	heuristics []heuristic
+}
+
+func (c ProviderConfig) resolve() (*resolvedProviders, error) {
+	heuristics, err := c.heuristics()
+	if err != nil {
+		return nil, err
+	}
+
+	explicit := make(map[string]Provider, len(c.ColumnProviders))
+	for col, providerName := range c.ColumnProviders {
+		p := lookupProvider(providerName)
+		if p == nil {
+			return nil, fmt.Errorf("unknown provider %q for column %q", providerName, col)
+		}
+		explicit[col] = p
+	}
+
+	locale := c.Locale
+	if locale == "" {
+		locale = "en_US"
+	}
+
+	return &resolvedProviders{locale: locale, explicit: explicit, heuristics: heuristics}, nil
+}
+
+// providerFor returns the Provider that should generate values for field on
+// in.table, or nil if none applies and the caller should fall back to the
+// default type-based generator.
+func (in *Insert) providerFor(field db.Field) Provider {
+	if in.providers == nil {
+		return nil
+	}
+	if p, ok := in.providers.explicit[in.table.Name+"."+field.ColumnName]; ok {
+		return p
+	}
+	for _, h := range in.providers.heuristics {
+		if h.re.MatchString(field.ColumnName) {
+			return lookupProvider(h.Provider)
+		}
+	}
+	return nil
+}
+
+// pick wraps a set of candidate values as a single randomly chosen Getter,
+// reusing the same enum-sampling machinery as "enum"/"set" columns.
+func pick(candidates []string, nullable bool, rnd *rand.Rand) Getter {
+	return NewRandomEnum(candidates, nullable, rnd)
+}
@@ -0,0 +1,212 @@
+package generate
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/ylacancellera/random-data-load/db"
+)
+
+// localeWords holds the word lists a locale-aware provider draws from. Only
+// en_US and fr_FR are seeded for now; add more locales by extending this map
+// or by registering a custom Provider via RegisterProvider.
+type localeWords struct {
+	firstNames []string
+	lastNames  []string
+	cities     []string
+	streets    []string
+	companies  []string
+	emailHosts []string
+
+	// nameCandidates/emailCandidates/addressCandidates are the cross
+	// products of the fields above (up to firstNames*lastNames*hosts
+	// entries), precomputed once in init() so nameProvider/emailProvider/
+	// addressProvider don't rebuild them on every generated row.
+	nameCandidates    []string
+	emailCandidates   []string
+	addressCandidates []string
+}
+
+var localeData = map[string]localeWords{
+	"en_US": {
+		firstNames: []string{"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda", "William", "Elizabeth"},
+		lastNames:  []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez"},
+		cities:     []string{"New York", "Los Angeles", "Chicago", "Houston", "Phoenix", "Philadelphia", "San Antonio", "San Diego"},
+		streets:    []string{"Main St", "Oak Ave", "Maple St", "Washington Blvd", "Park Rd", "2nd St", "Lake Dr"},
+		companies:  []string{"Acme Corp", "Globex", "Initech", "Umbrella LLC", "Stark Industries", "Wayne Enterprises"},
+		emailHosts: []string{"example.com", "mail.com", "example.org", "example.net"},
+	},
+	"fr_FR": {
+		firstNames: []string{"Jean", "Marie", "Pierre", "Camille", "Lucas", "Chloé", "Louis", "Emma", "Hugo", "Léa"},
+		lastNames:  []string{"Martin", "Bernard", "Dubois", "Thomas", "Robert", "Petit", "Durand", "Leroy", "Moreau", "Simon"},
+		cities:     []string{"Paris", "Marseille", "Lyon", "Toulouse", "Nice", "Nantes", "Strasbourg", "Montpellier"},
+		streets:    []string{"Rue de la Paix", "Avenue des Champs", "Rue Victor Hugo", "Boulevard Saint-Michel"},
+		companies:  []string{"Dupont SA", "Groupe Lumière", "Société Générale Fictive", "Nouvelle Vague SARL"},
+		emailHosts: []string{"exemple.fr", "courriel.fr", "exemple.com"},
+	},
+}
+
+func init() {
+	for key, l := range localeData {
+		l.nameCandidates = buildNameCandidates(l)
+		l.emailCandidates = buildEmailCandidates(l)
+		l.addressCandidates = buildAddressCandidates(l)
+		localeData[key] = l
+	}
+}
+
+func buildNameCandidates(l localeWords) []string {
+	candidates := make([]string, 0, len(l.firstNames)*len(l.lastNames))
+	for _, f := range l.firstNames {
+		for _, ln := range l.lastNames {
+			candidates = append(candidates, f+" "+ln)
+		}
+	}
+	return candidates
+}
+
+func buildEmailCandidates(l localeWords) []string {
+	candidates := make([]string, 0, len(l.firstNames)*len(l.lastNames)*len(l.emailHosts))
+	for _, f := range l.firstNames {
+		for _, ln := range l.lastNames {
+			for _, host := range l.emailHosts {
+				candidates = append(candidates, fmt.Sprintf("%s.%s@%s", toLower(f), toLower(ln), host))
+			}
+		}
+	}
+	return candidates
+}
+
+func buildAddressCandidates(l localeWords) []string {
+	candidates := make([]string, 0, len(l.streets)*100)
+	for _, s := range l.streets {
+		for n := 1; n <= 100; n += 7 {
+			candidates = append(candidates, fmt.Sprintf("%d %s", n, s))
+		}
+	}
+	return candidates
+}
+
+func locale(name string) localeWords {
+	if l, ok := localeData[name]; ok {
+		return l
+	}
+	return localeData["en_US"]
+}
+
+type nameProvider struct{}
+
+func (nameProvider) Generate(field db.Field, loc string, rnd *rand.Rand) Getter {
+	return pick(locale(loc).nameCandidates, field.IsNullable, rnd)
+}
+
+type emailProvider struct{}
+
+func (emailProvider) Generate(field db.Field, loc string, rnd *rand.Rand) Getter {
+	return pick(locale(loc).emailCandidates, field.IsNullable, rnd)
+}
+
+type phoneProvider struct{}
+
+var phoneCandidates = func() []string {
+	candidates := make([]string, 0, 1000)
+	for area := 200; area < 999; area += 37 {
+		for line := 1000; line < 9999; line += 733 {
+			candidates = append(candidates, fmt.Sprintf("+1-%03d-555-%04d", area%1000, line%10000))
+		}
+	}
+	return candidates
+}()
+
+func (phoneProvider) Generate(field db.Field, loc string, rnd *rand.Rand) Getter {
+	return pick(phoneCandidates, field.IsNullable, rnd)
+}
+
+type addressProvider struct{}
+
+func (addressProvider) Generate(field db.Field, loc string, rnd *rand.Rand) Getter {
+	return pick(locale(loc).addressCandidates, field.IsNullable, rnd)
+}
+
+type addressCityProvider struct{}
+
+func (addressCityProvider) Generate(field db.Field, loc string, rnd *rand.Rand) Getter {
+	return pick(locale(loc).cities, field.IsNullable, rnd)
+}
+
+type companyProvider struct{}
+
+func (companyProvider) Generate(field db.Field, loc string, rnd *rand.Rand) Getter {
+	return pick(locale(loc).companies, field.IsNullable, rnd)
+}
+
+var loremWords = []string{
+	"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit",
+	"sed", "do", "eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore",
+	"magna", "aliqua", "enim", "ad", "minim", "veniam", "quis", "nostrud",
+}
+
+var loremCandidates = func() []string {
+	candidates := make([]string, 0, len(loremWords))
+	sentence := ""
+	for i, w := range loremWords {
+		if i > 0 {
+			sentence += " "
+		}
+		sentence += w
+		candidates = append(candidates, sentence+".")
+	}
+	return candidates
+}()
+
+type loremProvider struct{}
+
+func (loremProvider) Generate(field db.Field, loc string, rnd *rand.Rand) Getter {
+	return pick(loremCandidates, field.IsNullable, rnd)
+}
+
+var creditCardCandidates = func() []string {
+	candidates := make([]string, 0, 200)
+	for i := 0; i < 200; i++ {
+		candidates = append(candidates, fmt.Sprintf("4%03d-%04d-%04d-%04d", (i*7)%1000, (i*97)%10000, (i*431)%10000, (i*991)%10000))
+	}
+	return candidates
+}()
+
+type creditCardProvider struct{}
+
+func (creditCardProvider) Generate(field db.Field, loc string, rnd *rand.Rand) Getter {
+	return pick(creditCardCandidates, field.IsNullable, rnd)
+}
+
+var ipCandidates = func() []string {
+	candidates := make([]string, 0, 256)
+	for i := 0; i < 256; i++ {
+		candidates = append(candidates, fmt.Sprintf("10.%d.%d.%d", (i*13)%256, (i*37)%256, (i*61)%256))
+	}
+	return candidates
+}()
+
+type ipProvider struct{}
+
+func (ipProvider) Generate(field db.Field, loc string, rnd *rand.Rand) Getter {
+	return pick(ipCandidates, field.IsNullable, rnd)
+}
+
+var uuidCandidates = generateUUIDCandidates(256)
+
+type uuidProvider struct{}
+
+func (uuidProvider) Generate(field db.Field, loc string, rnd *rand.Rand) Getter {
+	return pick(uuidCandidates, field.IsNullable, rnd)
+}
+
+func toLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if c >= 'A' && c <= 'Z' {
+			b[i] = c + ('a' - 'A')
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,45 @@
+package generate
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// deriveSeed mixes a base seed with a row index into a new, well-distributed
+// seed (splitmix64), so each row gets its own reproducible *rand.Rand without
+// the per-goroutine split in generateRows reordering output.
+func deriveSeed(seed, row int64) int64 {
+	z := uint64(seed) + uint64(row)*0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	z ^= z >> 31
+	return int64(z) //nolint:gosec
+}
+
+// hashSeed turns a string (e.g. a table name) into seed material so unrelated
+// samplers don't end up drawing from identical sequences.
+func hashSeed(s string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+	return int64(h.Sum64()) //nolint:gosec
+}
+
+// generateUUIDCandidates returns a deterministic, fixed-size pool of
+// version-4-shaped UUID strings, shared by the uuid provider
+// (provider_builtin.go) and PostgresDialect's "uuid" column type. Each
+// segment is masked to its exact hex width, since e.g. "%08x" only pads a
+// short value up to 8 digits - it doesn't truncate a longer one, and
+// i*2654435761 overflows 32 bits well before i reaches 256.
+func generateUUIDCandidates(n int) []string {
+	candidates := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		candidates = append(candidates, fmt.Sprintf("%08x-%04x-4%03x-%04x-%012x",
+			uint32(i*2654435761)&0xFFFFFFFF,
+			uint16(i)&0xFFFF,
+			uint16(i)&0xFFF,
+			uint16(i*7919)&0xFFFF,
+			uint64(i*2246822519)&0xFFFFFFFFFFFF,
+		))
+	}
+	return candidates
+}
@@ -0,0 +1,90 @@
+package generate
+
+import (
+	"math/rand"
+	"regexp/syntax"
+	"strings"
+)
+
+// generateFromRegex returns a random string matching pattern. It's built on
+// regexp/syntax rather than a matcher, so unbounded quantifiers (*, +, {n,})
+// are capped at maxRepeat repetitions instead of running forever.
+func generateFromRegex(pattern string, maxRepeat int, rnd *rand.Rand) (string, error) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	writeRegexNode(re.Simplify(), maxRepeat, rnd, &b)
+	return b.String(), nil
+}
+
+func writeRegexNode(re *syntax.Regexp, maxRepeat int, rnd *rand.Rand, b *strings.Builder) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		for _, r := range re.Rune {
+			b.WriteRune(r)
+		}
+	case syntax.OpCharClass:
+		b.WriteRune(randRuneFromClass(re.Rune, rnd))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune(rune('a' + rnd.Intn(26)))
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writeRegexNode(sub, maxRepeat, rnd, b)
+		}
+	case syntax.OpAlternate:
+		writeRegexNode(re.Sub[rnd.Intn(len(re.Sub))], maxRepeat, rnd, b)
+	case syntax.OpCapture:
+		writeRegexNode(re.Sub[0], maxRepeat, rnd, b)
+	case syntax.OpStar:
+		for i, n := 0, rnd.Intn(maxRepeat+1); i < n; i++ {
+			writeRegexNode(re.Sub[0], maxRepeat, rnd, b)
+		}
+	case syntax.OpPlus:
+		for i, n := 0, 1+rnd.Intn(maxRepeat); i < n; i++ {
+			writeRegexNode(re.Sub[0], maxRepeat, rnd, b)
+		}
+	case syntax.OpQuest:
+		if rnd.Intn(2) == 0 {
+			writeRegexNode(re.Sub[0], maxRepeat, rnd, b)
+		}
+	case syntax.OpRepeat:
+		min, max := re.Min, re.Max
+		if max < 0 || max > maxRepeat {
+			max = maxRepeat
+		}
+		if min > max {
+			min = max
+		}
+		n := min
+		if max > min {
+			n += rnd.Intn(max - min + 1)
+		}
+		for i := 0; i < n; i++ {
+			writeRegexNode(re.Sub[0], maxRepeat, rnd, b)
+		}
+	}
+	// Zero-width assertions (^, $, \b, ...) and OpEmptyMatch emit nothing.
+}
+
+// randRuneFromClass picks a random rune from a regexp/syntax character class,
+// which is encoded as a flat list of [lo, hi] rune range pairs.
+func randRuneFromClass(pairs []rune, rnd *rand.Rand) rune {
+	total := 0
+	for i := 0; i < len(pairs); i += 2 {
+		total += int(pairs[i+1]-pairs[i]) + 1
+	}
+	if total <= 0 {
+		return '?'
+	}
+	n := rnd.Intn(total)
+	for i := 0; i < len(pairs); i += 2 {
+		width := int(pairs[i+1]-pairs[i]) + 1
+		if n < width {
+			return pairs[i] + rune(n)
+		}
+		n -= width
+	}
+	return pairs[0]
+}